@@ -0,0 +1,84 @@
+package syslog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapMetricToRFC3164(t *testing.T) {
+	newMetric := func(fields map[string]interface{}) telegraf.Metric {
+		m, err := metric.New("testmetric", nil, fields, time.Date(2020, time.March, 5, 1, 2, 3, 0, time.UTC))
+		require.NoError(t, err)
+		return m
+	}
+
+	tests := []struct {
+		name     string
+		fields   map[string]interface{}
+		mutate   func(s *Syslog)
+		expected string
+	}{
+		{
+			name:     "defaults",
+			fields:   map[string]interface{}{"MSG": "hello"},
+			expected: "<0>Mar  5 01:02:03 hostname Telegraf: hello",
+		},
+		{
+			name:     "PRI and APP-NAME fields override defaults",
+			fields:   map[string]interface{}{"PRI": uint64(13), "APP-NAME": "myapp", "MSG": "hi"},
+			expected: "<13>Mar  5 01:02:03 hostname myapp: hi",
+		},
+		{
+			name:     "PROCID is appended to the tag",
+			fields:   map[string]interface{}{"APP-NAME": "myapp", "PROCID": uint64(4242), "MSG": "hi"},
+			expected: "<0>Mar  5 01:02:03 hostname myapp[4242]: hi",
+		},
+		{
+			name: "TAG longer than rfc3164TagMaxLength is truncated",
+			fields: map[string]interface{}{
+				"APP-NAME": strings.Repeat("x", rfc3164TagMaxLength+10),
+				"MSG":      "hi",
+			},
+			expected: fmt.Sprintf("<0>Mar  5 01:02:03 hostname %s: hi", strings.Repeat("x", rfc3164TagMaxLength)),
+		},
+		{
+			name:     "HOSTNAME field is used when present",
+			fields:   map[string]interface{}{"HOSTNAME": "web01", "MSG": "hi"},
+			expected: "<0>Mar  5 01:02:03 web01 Telegraf: hi",
+		},
+		{
+			name:     "SOURCE field is used when HOSTNAME is absent",
+			fields:   map[string]interface{}{"SOURCE": "web02", "MSG": "hi"},
+			expected: "<0>Mar  5 01:02:03 web02 Telegraf: hi",
+		},
+		{
+			name:     "single-digit day of month is space-padded",
+			fields:   map[string]interface{}{"MSG": "hi"},
+			expected: "<0>Mar  5 01:02:03 hostname Telegraf: hi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSyslog()
+			if tt.mutate != nil {
+				tt.mutate(s)
+			}
+			if _, ok := tt.fields["HOSTNAME"]; !ok {
+				if _, ok := tt.fields["SOURCE"]; !ok {
+					tt.fields["HOSTNAME"] = "hostname"
+				}
+			}
+
+			out, err := s.mapMetricToRFC3164(newMetric(tt.fields))
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(out))
+		})
+	}
+}
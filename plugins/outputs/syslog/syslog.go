@@ -2,6 +2,7 @@ package syslog
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/go-syslog/nontransparent"
@@ -19,6 +21,19 @@ import (
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
+// Syslog message formats supported via the Format setting.
+const (
+	rfc5424Format = "rfc5424"
+	rfc3164Format = "rfc3164"
+)
+
+// rfc3164TagMaxLength is the maximum length of the TAG field allowed by RFC3164#section-4.1.3.
+const rfc3164TagMaxLength = 32
+
+// rfc3164TimeLayout formats a timestamp the way RFC3164#section-4.1.2 expects,
+// i.e. a space-padded day of month.
+const rfc3164TimeLayout = "Jan _2 15:04:05"
+
 type Syslog struct {
 	Address         string
 	KeepAlivePeriod *internal.Duration
@@ -26,12 +41,25 @@ type Syslog struct {
 	DefaultPriority uint64
 	DefaultAppname  string
 	Sdids           []string
+	Format          string `toml:"syslog_format"`
 	Framing         Framing
 	Trailer         nontransparent.TrailerType
-	Separator       string `toml:"sdparam_separator"`
+	Separator       string             `toml:"sdparam_separator"`
+	QueueSize       int                `toml:"queue_size"`
+	ReconnectMin    *internal.Duration `toml:"reconnect_min"`
+	ReconnectMax    *internal.Duration `toml:"reconnect_max"`
+	SendTimeout     *internal.Duration `toml:"send_timeout"`
 	net.Conn
 	tlsint.ClientConfig
 	reservedFields map[string]bool
+
+	// mu guards Conn and queue together. Write checks both under it, so after
+	// a reconnect it keeps queueing behind the backlog queued while the socket
+	// was down until that backlog has been drained, instead of sending ahead
+	// of it on the live Conn.
+	mu      sync.Mutex
+	queue   [][]byte
+	closing chan struct{}
 }
 
 var sampleConfig = `
@@ -44,6 +72,8 @@ var sampleConfig = `
 # address = "udp://127.0.0.1:8094"
 # address = "udp4://127.0.0.1:8094"
 # address = "udp6://127.0.0.1:8094"
+# address = "unix:///var/run/telegraf.sock"
+# address = "unixgram:///dev/log"
 
 ## Optional TLS Config
 # tls_ca = "/etc/telegraf/ca.pem"
@@ -58,6 +88,13 @@ var sampleConfig = `
 ## Defaults to the OS configuration.
 # keep_alive_period = "5m"
 
+## Syslog message format to use when serializing metrics (default = "rfc5424").
+## "rfc5424" produces structured-data messages per RFC5424.
+## "rfc3164" produces legacy BSD-style messages per RFC3164, for collectors
+## that don't accept RFC5424 structured data.
+## Must be one of "rfc5424", "rfc3164".
+# syslog_format = "rfc5424"
+
 ## The framing technique with which it is expected that messages are transported (default = "octet-counting").
 ## Whether the messages come using the octect-counting (RFC5425#section-4.3.1, RFC6587#section-3.4.1),
 ## or the non-transparent framing technique (RFC6587#section-3.4.2).
@@ -68,6 +105,19 @@ var sampleConfig = `
 ## Must be one of "LF", or "NUL".
 # trailer = "LF"
 
+## Number of framed messages to buffer in memory while the connection is
+## down, so they can be sent once it's reconnected (default = 1000).
+# queue_size = 1000
+
+## Minimum and maximum time to wait between reconnect attempts. Backs off
+## exponentially from reconnect_min up to reconnect_max.
+# reconnect_min = "500ms"
+# reconnect_max = "60s"
+
+## Maximum time to wait for a single write to the socket to complete before
+## treating the connection as down.
+# send_timeout = "5s"
+
 ### SD-PARAMs settings
 ### A syslog message can contain multiple parameters and multiple identifiers within structured data section
 ### For each unrecognised metric field a SD-PARAMS can be created. 
@@ -97,42 +147,137 @@ default_priority = 0
 default_appname = "Telegraf"
 `
 
+// errClosing is returned internally by connectAndDrain when Close() fired
+// while it was dialing; the reconnect loop treats it like a shutdown rather
+// than a failed attempt to retry.
+var errClosing = errors.New("syslog: closing")
+
 func (s *Syslog) Connect() error {
+	closing := make(chan struct{})
+	if err := s.connectAndDrain(closing); err != nil {
+		return err
+	}
+
+	s.closing = closing
+	go s.reconnectLoop(closing)
+	return nil
+}
+
+// openConn dials s.Address, returning a fresh connection without touching
+// s.Conn or the pending queue.
+func (s *Syslog) openConn() (net.Conn, error) {
 	spl := strings.SplitN(s.Address, "://", 2)
 	if len(spl) != 2 {
-		return fmt.Errorf("invalid address: %s", s.Address)
+		return nil, fmt.Errorf("invalid address: %s", s.Address)
 	}
+	scheme := spl[0]
 
-	tlsCfg, err := s.ClientConfig.TLSConfig()
+	var c net.Conn
+	var err error
+	switch scheme {
+	case "unix", "unixgram":
+		// Unix domain sockets are local; there's no TLS or keep alive to
+		// negotiate for them.
+		c, err = net.Dial(scheme, spl[1])
+	default:
+		tlsCfg, tlsErr := s.ClientConfig.TLSConfig()
+		if tlsErr != nil {
+			return nil, tlsErr
+		}
+		if tlsCfg == nil {
+			c, err = net.Dial(scheme, spl[1])
+		} else {
+			c, err = tls.Dial(scheme, spl[1], tlsCfg)
+		}
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var c net.Conn
-	if tlsCfg == nil {
-		c, err = net.Dial(spl[0], spl[1])
-	} else {
-		c, err = tls.Dial(spl[0], spl[1], tlsCfg)
+	if err := s.setKeepAlive(c); err != nil {
+		log.Printf("unable to configure keep alive (%s): %s", s.Address, err)
 	}
+	return c, nil
+}
+
+// connectAndDrain dials s.Address and installs the new connection, then
+// drains anything queued while the socket was down. closing is checked right
+// after the dial succeeds, before the connection is installed, so a Close()
+// that ran while this was blocked in net.Dial can't be raced: the freshly
+// dialed socket is closed immediately instead of being handed to Write.
+func (s *Syslog) connectAndDrain(closing chan struct{}) error {
+	c, err := s.openConn()
 	if err != nil {
 		return err
 	}
 
-	if err := s.setKeepAlive(c); err != nil {
-		log.Printf("unable to configure keep alive (%s): %s", s.Address, err)
+	select {
+	case <-closing:
+		c.Close()
+		return errClosing
+	default:
 	}
 
+	s.mu.Lock()
 	s.Conn = c
+	s.mu.Unlock()
+
+	// Write sees a non-nil Conn with a non-empty queue and keeps enqueueing
+	// behind the backlog (see Write), so draining without holding mu for
+	// every send doesn't let a concurrent write jump ahead of it - it only
+	// stops a slow remote from stalling Write for the whole backlog.
+	s.drainQueue()
 	return nil
 }
 
+// reconnectLoop watches for a dropped connection and re-dials with an
+// exponential backoff between reconnect_min and reconnect_max. Once
+// reconnected, it drains any messages queued while the socket was down.
+// closing is captured once from Connect so Close() closing the channel and
+// clearing the field can never race this goroutine's select.
+func (s *Syslog) reconnectLoop(closing chan struct{}) {
+	backoff := s.ReconnectMin.Duration
+	for {
+		select {
+		case <-closing:
+			return
+		case <-time.After(backoff):
+		}
+
+		s.mu.Lock()
+		connected := s.Conn != nil
+		s.mu.Unlock()
+		if connected {
+			backoff = s.ReconnectMin.Duration
+			continue
+		}
+
+		if err := s.connectAndDrain(closing); err != nil {
+			if err == errClosing {
+				return
+			}
+			backoff *= 2
+			if backoff > s.ReconnectMax.Duration {
+				backoff = s.ReconnectMax.Duration
+			}
+			continue
+		}
+
+		log.Printf("D! [outputs.syslog] reconnected to %s", s.Address)
+		backoff = s.ReconnectMin.Duration
+	}
+}
+
 func (s *Syslog) setKeepAlive(c net.Conn) error {
 	if s.KeepAlivePeriod == nil {
 		return nil
 	}
 	tcpc, ok := c.(*net.TCPConn)
 	if !ok {
-		return fmt.Errorf("cannot set keep alive on a %s socket", strings.SplitN(s.Address, "://", 2)[0])
+		// Keep alive only applies to TCP sockets; silently skip it for
+		// other transports (e.g. UDP or Unix domain sockets) instead of
+		// logging a spurious error.
+		return nil
 	}
 	if s.KeepAlivePeriod.Duration == 0 {
 		return tcpc.SetKeepAlive(false)
@@ -144,12 +289,20 @@ func (s *Syslog) setKeepAlive(c net.Conn) error {
 }
 
 func (s *Syslog) Close() error {
-	if s.Conn == nil {
+	s.mu.Lock()
+	closing := s.closing
+	s.closing = nil
+	conn := s.Conn
+	s.Conn = nil
+	s.mu.Unlock()
+
+	if closing != nil {
+		close(closing)
+	}
+	if conn == nil {
 		return nil
 	}
-	err := s.Conn.Close()
-	s.Conn = nil
-	return err
+	return conn.Close()
 }
 
 func (s *Syslog) SampleConfig() string {
@@ -161,27 +314,97 @@ func (s *Syslog) Description() string {
 }
 
 func (s *Syslog) Write(metrics []telegraf.Metric) error {
-	if s.Conn == nil {
-		// previous write failed with permanent error and socket was closed.
-		if err := s.Connect(); err != nil {
+	for _, metric := range metrics {
+		msgBytes, err := s.encode(metric)
+		if err != nil {
+			continue
+		}
+		framed := s.getSyslogMessageBytesWithFraming(msgBytes)
+
+		s.mu.Lock()
+		// If the socket is down, or there's still a backlog waiting to be
+		// drained, this message has to queue behind it rather than jump
+		// ahead via a direct send.
+		if s.Conn == nil || len(s.queue) > 0 {
+			s.enqueueLocked(framed)
+			s.mu.Unlock()
+			continue
+		}
+		conn := s.Conn
+		s.mu.Unlock()
+
+		if err := s.send(conn, framed); err != nil {
+			log.Printf("D! [outputs.syslog] write to %s failed: %s; queueing for retry", s.Address, err)
+			s.mu.Lock()
+			if s.Conn == conn {
+				s.Conn.Close()
+				s.Conn = nil
+			}
+			s.enqueueLocked(framed)
+			s.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// send writes a framed message to conn, applying send_timeout as a write
+// deadline.
+func (s *Syslog) send(conn net.Conn, framed []byte) error {
+	if s.SendTimeout != nil && s.SendTimeout.Duration > 0 {
+		if err := conn.SetWriteDeadline(time.Now().Add(s.SendTimeout.Duration)); err != nil {
 			return err
 		}
 	}
+	_, err := conn.Write(framed)
+	return err
+}
 
-	for _, metric := range metrics {
-		if msg, err := s.mapMetricToSyslogMessage(metric); err == nil {
-			msgBytesWithObjectCounting := s.getSyslogMessageBytesWithFraming(msg)
-			if _, err := s.Conn.Write(msgBytesWithObjectCounting); err != nil {
-				if err, ok := err.(net.Error); !ok || !err.Temporary() {
-					s.Close()
-					s.Conn = nil
-					return fmt.Errorf("closing connection: %v", err)
-				}
-				return err
+// enqueueLocked appends a framed message to the pending queue, dropping the
+// oldest entry if the queue is already at queue_size. A queue_size of 0 or
+// less means the queue can never hold more than the message being appended.
+// Callers must hold mu.
+func (s *Syslog) enqueueLocked(framed []byte) {
+	if len(s.queue) > 0 && len(s.queue) >= s.QueueSize {
+		log.Printf("D! [outputs.syslog] queue full (%d), dropping oldest message", s.QueueSize)
+		s.queue = s.queue[1:]
+	}
+	s.queue = append(s.queue, framed)
+}
+
+// drainQueue flushes any messages queued while the socket was down, in
+// order, stopping at the first failure so the rest remain queued for the
+// next reconnect. Each message is sent without holding mu and removed from
+// the queue only after its send succeeds, so a
+// slow remote blocks at most one send_timeout at a time instead of stalling
+// every concurrent Write for the whole backlog.
+func (s *Syslog) drainQueue() {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 || s.Conn == nil {
+			s.mu.Unlock()
+			return
+		}
+		conn := s.Conn
+		framed := s.queue[0]
+		s.mu.Unlock()
+
+		if err := s.send(conn, framed); err != nil {
+			log.Printf("D! [outputs.syslog] draining queue to %s failed: %s", s.Address, err)
+			s.mu.Lock()
+			if s.Conn == conn {
+				s.Conn.Close()
+				s.Conn = nil
 			}
+			s.mu.Unlock()
+			return
+		}
+
+		s.mu.Lock()
+		if len(s.queue) > 0 {
+			s.queue = s.queue[1:]
 		}
+		s.mu.Unlock()
 	}
-	return nil
 }
 
 func formatValue(value interface{}) string {
@@ -211,6 +434,24 @@ func formatValue(value interface{}) string {
 	return ""
 }
 
+// encode serializes metric according to the configured Format, returning the
+// unframed message bytes.
+func (s *Syslog) encode(metric telegraf.Metric) ([]byte, error) {
+	if s.Format == rfc3164Format {
+		return s.mapMetricToRFC3164(metric)
+	}
+
+	msg, err := s.mapMetricToSyslogMessage(metric)
+	if err != nil {
+		return nil, err
+	}
+	msgString, err := msg.String()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(msgString), nil
+}
+
 func (s *Syslog) mapMetricToSyslogMessage(metric telegraf.Metric) (*rfc5424.SyslogMessage, error) {
 	msg := &rfc5424.SyslogMessage{}
 	msg.SetVersion(1)
@@ -275,10 +516,47 @@ func (s *Syslog) mapMetricToSyslogMessage(metric telegraf.Metric) (*rfc5424.Sysl
 	return msg, nil
 }
 
-func (s *Syslog) getSyslogMessageBytesWithFraming(msg *rfc5424.SyslogMessage) []byte {
-	msgString, _ := msg.String()
-	msgBytes := []byte(msgString)
+// mapMetricToRFC3164 builds a classic BSD syslog message
+// (<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG) per RFC3164.
+func (s *Syslog) mapMetricToRFC3164(metric telegraf.Metric) ([]byte, error) {
+	pri := s.DefaultPriority
+	if value, ok := metric.GetField("PRI"); ok {
+		if v, err := strconv.ParseUint(formatValue(value), 10, 8); err == nil {
+			pri = v
+		}
+	}
+
+	tag := s.DefaultAppname
+	if value, ok := metric.GetField("APP-NAME"); ok {
+		tag = formatValue(value)
+	}
+	if len(tag) > rfc3164TagMaxLength {
+		tag = tag[:rfc3164TagMaxLength]
+	}
+	if value, ok := metric.GetField("PROCID"); ok {
+		tag = fmt.Sprintf("%s[%s]", tag, formatValue(value))
+	}
 
+	// Try with HOSTNAME, then with SOURCE, then take OS Hostname
+	hostname := ""
+	if value, ok := metric.GetField("HOSTNAME"); ok {
+		hostname = formatValue(value)
+	} else if value, ok := metric.GetField("SOURCE"); ok {
+		hostname = formatValue(value)
+	} else if value, err := os.Hostname(); err == nil {
+		hostname = value
+	}
+
+	msg := ""
+	if value, ok := metric.GetField("MSG"); ok {
+		msg = formatValue(value)
+	}
+
+	timestamp := metric.Time().Format(rfc3164TimeLayout)
+	return []byte(fmt.Sprintf("<%d>%s %s %s: %s", pri, timestamp, hostname, tag, msg)), nil
+}
+
+func (s *Syslog) getSyslogMessageBytesWithFraming(msgBytes []byte) []byte {
 	if s.Framing == OctetCounting {
 		return append([]byte(strconv.Itoa(len(msgBytes))+" "), msgBytes...)
 	}
@@ -291,14 +569,19 @@ func newSyslog() *Syslog {
 		reservedFields: map[string]bool{
 			"PRI": true, "HOSTNAME": true, "APP-NAME": true,
 			"PROCID": true, "MSGID": true, "MSG": true},
+		Format:          rfc5424Format,
 		Framing:         OctetCounting,
 		Trailer:         nontransparent.LF,
 		Separator:       "_",
 		DefaultPriority: uint64(0),
 		DefaultAppname:  "Telegraf",
+		QueueSize:       1000,
+		ReconnectMin:    &internal.Duration{Duration: 500 * time.Millisecond},
+		ReconnectMax:    &internal.Duration{Duration: 60 * time.Second},
+		SendTimeout:     &internal.Duration{Duration: 5 * time.Second},
 	}
 }
 
 func init() {
 	outputs.Add("syslog", func() telegraf.Output { return newSyslog() })
-}
\ No newline at end of file
+}
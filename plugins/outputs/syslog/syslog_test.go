@@ -0,0 +1,114 @@
+package syslog
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReconnectDrainsQueueBeforeNewWrites drops a live connection out from
+// under the plugin, queues metrics while it's down, then lets the
+// background reconnect loop redial while new writes keep coming in. Every
+// message that was queued while disconnected must reach the server before
+// any message written after the reconnect - a concurrent Write must never
+// be able to bypass the backlog while it's being drained.
+func TestReconnectDrainsQueueBeforeNewWrites(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	var mu sync.Mutex
+	var received []byte
+	accepted := make(chan struct{}, 8)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- struct{}{}
+			go func(c net.Conn) {
+				buf := make([]byte, 4096)
+				for {
+					n, err := c.Read(buf)
+					if n > 0 {
+						mu.Lock()
+						received = append(received, buf[:n]...)
+						mu.Unlock()
+					}
+					if err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	s := newSyslog()
+	s.Address = "tcp://" + ln.Addr().String()
+	s.ReconnectMin = &internal.Duration{Duration: 5 * time.Millisecond}
+	s.ReconnectMax = &internal.Duration{Duration: 20 * time.Millisecond}
+	s.SendTimeout = &internal.Duration{Duration: time.Second}
+
+	require.NoError(t, s.Connect())
+	defer s.Close()
+	<-accepted
+
+	newMetric := func(msg string) telegraf.Metric {
+		m, err := metric.New("testmetric", nil, map[string]interface{}{"MSG": msg}, time.Now())
+		require.NoError(t, err)
+		return m
+	}
+
+	// Simulate the socket dropping out from under the plugin.
+	s.mu.Lock()
+	s.Conn.Close()
+	s.Conn = nil
+	s.mu.Unlock()
+
+	require.NoError(t, s.Write([]telegraf.Metric{newMetric("queued-1"), newMetric("queued-2")}))
+
+	s.mu.Lock()
+	queued := len(s.queue)
+	s.mu.Unlock()
+	require.Equal(t, 2, queued, "writes while disconnected should be queued, not dropped")
+
+	// Hammer Write with fresh metrics while the background loop is redialing
+	// and draining, to try to land one in the window between Conn being set
+	// and the backlog being flushed.
+	var names []string
+	for i := 0; i < 30; i++ {
+		name := "live-" + string(rune('a'+i%26)) + time.Now().Format("150405.000000000")
+		names = append(names, name)
+		require.NoError(t, s.Write([]telegraf.Metric{newMetric(name)}))
+		time.Sleep(time.Millisecond)
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Contains(string(received), names[len(names)-1])
+	}, 2*time.Second, 10*time.Millisecond, "last live metric never arrived")
+
+	mu.Lock()
+	out := string(received)
+	mu.Unlock()
+
+	i1 := strings.Index(out, "queued-1")
+	i2 := strings.Index(out, "queued-2")
+	require.True(t, i1 >= 0 && i2 >= 0, "expected both queued metrics to arrive: %q", out)
+	require.Less(t, i1, i2, "queued metrics must arrive in FIFO order")
+
+	for _, name := range names {
+		if idx := strings.Index(out, name); idx >= 0 {
+			require.Greater(t, idx, i2, "%q must not reach the server ahead of the drained backlog", name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package syslog
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConnectUnixSocket exercises the "unix" transport branch of openConn,
+// which skips TLS and keep alive negotiation entirely for local sockets.
+func TestConnectUnixSocket(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "telegraf.sock")
+	ln, err := net.Listen("unix", sockPath)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 4096)
+		n, _ := conn.Read(buf)
+		received <- buf[:n]
+	}()
+
+	s := newSyslog()
+	s.Address = "unix://" + sockPath
+
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	m, err := metric.New("testmetric", nil, map[string]interface{}{"MSG": "hi"}, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, s.Write([]telegraf.Metric{m}))
+
+	select {
+	case msg := <-received:
+		require.True(t, len(msg) > 0, "expected a non-empty message over the unix socket")
+	case <-time.After(2 * time.Second):
+		t.Fatal("message never arrived over the unix socket")
+	}
+}
+
+// TestSetKeepAliveSkipsNonTCPConns confirms that setKeepAlive degrades
+// gracefully instead of erroring out for a transport that doesn't support
+// keep alive, here the in-memory net.Pipe, like the unix/unixgram sockets.
+func TestSetKeepAliveSkipsNonTCPConns(t *testing.T) {
+	client, srv := net.Pipe()
+	defer client.Close()
+	defer srv.Close()
+
+	s := newSyslog()
+	s.KeepAlivePeriod = &internal.Duration{Duration: 5 * time.Minute}
+
+	require.NoError(t, s.setKeepAlive(client))
+}